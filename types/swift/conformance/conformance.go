@@ -0,0 +1,211 @@
+// Package conformance builds a runtime-style protocol conformance lookup
+// index over already-parsed Mach-O images, mirroring what
+// swift_conformsToProtocol does against live metadata, but over each
+// image's __swift5_proto conformance records instead.
+package conformance
+
+import (
+	"sync"
+
+	"github.com/blacktop/go-macho/types/swift/protocols"
+)
+
+// Image is a single parsed Mach-O image - the main binary, or one of the
+// dyld shared cache dylibs it was loaded against - contributing protocol
+// conformance records to an Index.
+type Image struct {
+	Name         string
+	Conformances []*protocols.ConformanceDescriptor
+}
+
+// Key uniquely identifies a protocol conformance record the way the Swift
+// runtime's own conformance cache does: by the addresses of the conforming
+// type's descriptor and the protocol's descriptor. ObjCClassName is set
+// instead of TypeDescriptorAddr for conformances recorded against an
+// Objective-C class by name (referenceKind DirectObjCClassName /
+// IndirectObjCClass), since those have no type descriptor to key on.
+type Key struct {
+	TypeDescriptorAddr     uint64
+	ObjCClassName          string
+	ProtocolDescriptorAddr uint64
+}
+
+// Conflict records two conformance records that were registered for the
+// same Key. Per the ABI, this is only a real conflict when at least one
+// side is retroactive; conformances synthesized non-uniquely for the same
+// key are guaranteed equivalent and never reported as conflicts.
+type Conflict struct {
+	Key      Key
+	Existing *protocols.ConformanceDescriptor
+	Rejected *protocols.ConformanceDescriptor
+}
+
+// Index is a concurrent lookup table over every protocol conformance
+// record parsed across a set of loaded images, answering the same question
+// swift_conformsToProtocol answers at runtime: does this type conform to
+// this protocol, and if so, via which conformance descriptor.
+type Index struct {
+	mu        sync.RWMutex
+	table     map[Key]*protocols.ConformanceDescriptor
+	Conflicts []Conflict
+}
+
+// NewIndex builds an Index from every conformance record across images.
+// Images are processed in order, and the first registration for a given
+// Key wins and is never replaced, matching the runtime's append-only
+// conformance cache. A later registration is recorded in Conflicts only
+// when either side is retroactive and the two are not both synthesized
+// non-uniquely (see Add); otherwise it is dropped.
+func NewIndex(images ...Image) *Index {
+	idx := &Index{table: make(map[Key]*protocols.ConformanceDescriptor)}
+	for _, img := range images {
+		for _, cd := range img.Conformances {
+			idx.Add(cd)
+		}
+	}
+	return idx
+}
+
+// Add registers a single conformance record, e.g. one discovered after the
+// index was built, such as a dylib dlopen'd later in the process. It is
+// safe for concurrent use.
+func (idx *Index) Add(cd *protocols.ConformanceDescriptor) {
+	key, ok := keyFor(cd)
+	if !ok {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	existing, found := idx.table[key]
+	if !found {
+		idx.table[key] = cd
+		return
+	}
+	if existing.Flags.IsSynthesizedNonUnique() && cd.Flags.IsSynthesizedNonUnique() {
+		return
+	}
+	if existing.Flags.IsRetroactive() || cd.Flags.IsRetroactive() {
+		idx.Conflicts = append(idx.Conflicts, Conflict{Key: key, Existing: existing, Rejected: cd})
+	}
+}
+
+// keyFor computes cd's lookup Key from its resolved TypeRef/ProtocolRef,
+// branching on the conformance's referenceKind the same way the runtime
+// does when walking a conformance record: DirectTypeDescriptor and
+// IndirectTypeDescriptor both key on the (already-resolved) type
+// descriptor's address, while DirectObjCClassName and IndirectObjCClass
+// key on the Objective-C class name instead. It returns false if cd hasn't
+// been resolved enough to compute a stable key (e.g. ProtocolRef wasn't
+// populated by the caller).
+func keyFor(cd *protocols.ConformanceDescriptor) (Key, bool) {
+	if cd == nil || cd.ProtocolRef == nil {
+		return Key{}, false
+	}
+	key := Key{ProtocolDescriptorAddr: cd.ProtocolRef.Address}
+	switch cd.Flags.GetTypeReferenceKind() {
+	case protocols.DirectTypeDescriptor, protocols.IndirectTypeDescriptor:
+		if cd.TypeRef == nil {
+			return Key{}, false
+		}
+		key.TypeDescriptorAddr = cd.TypeRef.Address
+	case protocols.DirectObjCClassName, protocols.IndirectObjCClass:
+		if cd.TypeRef == nil || cd.TypeRef.Name == "" {
+			return Key{}, false
+		}
+		key.ObjCClassName = cd.TypeRef.Name
+	default:
+		return Key{}, false
+	}
+	return key, true
+}
+
+// Lookup mirrors swift_conformsToProtocol: does the type at
+// typeDescriptorAddr conform to the protocol at protocolDescriptorAddr,
+// according to this index?
+func (idx *Index) Lookup(typeDescriptorAddr, protocolDescriptorAddr uint64) (*protocols.ConformanceDescriptor, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	cd, ok := idx.table[Key{TypeDescriptorAddr: typeDescriptorAddr, ProtocolDescriptorAddr: protocolDescriptorAddr}]
+	return cd, ok
+}
+
+// LookupObjC is Lookup's counterpart for conformances recorded against an
+// Objective-C class name rather than a type descriptor address.
+func (idx *Index) LookupObjC(objcClassName string, protocolDescriptorAddr uint64) (*protocols.ConformanceDescriptor, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	cd, ok := idx.table[Key{ObjCClassName: objcClassName, ProtocolDescriptorAddr: protocolDescriptorAddr}]
+	return cd, ok
+}
+
+// ConformancesOf returns every protocol conformance recorded against the
+// type at typeDescriptorAddr. typeDescriptorAddr of 0 is never a valid
+// query: it's the zero value left on Key.TypeDescriptorAddr for
+// ObjC-class-name-keyed conformances (see keyFor), so it always returns
+// nil rather than conflating every such conformance in the index together;
+// use ConformancesOfObjC to query those by class name instead.
+func (idx *Index) ConformancesOf(typeDescriptorAddr uint64) []*protocols.ConformanceDescriptor {
+	if typeDescriptorAddr == 0 {
+		return nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var out []*protocols.ConformanceDescriptor
+	for k, cd := range idx.table {
+		if k.TypeDescriptorAddr == typeDescriptorAddr {
+			out = append(out, cd)
+		}
+	}
+	return out
+}
+
+// ConformancesOfObjC returns every protocol conformance recorded against
+// the Objective-C class objcClassName, the ConformancesOf counterpart for
+// conformances keyed by class name rather than type descriptor address
+// (see Key.ObjCClassName).
+func (idx *Index) ConformancesOfObjC(objcClassName string) []*protocols.ConformanceDescriptor {
+	if objcClassName == "" {
+		return nil
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var out []*protocols.ConformanceDescriptor
+	for k, cd := range idx.table {
+		if k.ObjCClassName == objcClassName {
+			out = append(out, cd)
+		}
+	}
+	return out
+}
+
+// Substitution records, for a conditionally-conforming generic type, which
+// of its conditional requirements (keyed by TargetGenericRequirementDescriptor.Param,
+// the raw relative offset to the constrained parameter's mangled name) are known to hold
+// for a particular instantiation - e.g. that Array<Int>'s "Element:
+// Sendable" requirement is satisfied because Int is known to be Sendable.
+type Substitution map[int32]bool
+
+// SatisfiesConditionalRequirements reports whether every conditional
+// requirement on cd holds under subs, letting a caller answer e.g. "does
+// Array<Int> conform to Sendable in this binary" from cd's conditional
+// requirements alone, without a type-checker: Array's "Element: Sendable"
+// protocol requirement is looked up by its Param in subs.
+//
+// Only GRKindProtocol and GRKindSameConformance requirements are evaluated
+// this way; GRKindSameType and GRKindLayout requirements constrain a
+// parameter's identity or layout rather than its protocol conformances, and
+// this conservatively reports those as unsatisfied since subs has no way to
+// express them.
+func SatisfiesConditionalRequirements(cd *protocols.ConformanceDescriptor, subs Substitution) bool {
+	for _, req := range cd.ConditionalRequirements {
+		switch req.Flags.Kind() {
+		case protocols.GRKindProtocol, protocols.GRKindSameConformance:
+			if !subs[req.Param] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
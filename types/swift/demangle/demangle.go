@@ -0,0 +1,572 @@
+// Package demangle parses and pretty-prints Swift's stable mangling scheme,
+// the format used to encode the type and protocol names embedded in Swift
+// reflection metadata (__swift5_fieldmd, __swift5_proto, __swift5_assocty, ...).
+//
+// This covers the postfix grammar for nominal type contexts (modules,
+// classes, structs, enums, protocols), bound generics, tuples, function
+// types and the metadata/witness-table accessor suffixes. It does not yet
+// decode the standard-library single-letter type abbreviations (`Si` for
+// Swift.Int, `SS` for Swift.String, ...), labeled tuple elements, or
+// generic parameter packs; unsupported symbols are returned as an error
+// rather than silently mis-parsed.
+//
+// ref: swift/docs/ABI/Mangling.rst
+package demangle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies the grammar production a Node was parsed from.
+type Kind uint8
+
+const (
+	Module Kind = iota
+	Identifier
+	Class
+	Struct
+	Enum
+	Protocol
+	BoundGenericClass
+	BoundGenericStruct
+	BoundGenericEnum
+	BoundGenericProtocol
+	TypeList
+	Tuple
+	FunctionType
+	Function
+	Metadata
+	MetadataAccessor
+	ProtocolWitnessTable
+	ProtocolConformanceDescriptor
+	Global
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Module:
+		return "Module"
+	case Identifier:
+		return "Identifier"
+	case Class:
+		return "Class"
+	case Struct:
+		return "Struct"
+	case Enum:
+		return "Enum"
+	case Protocol:
+		return "Protocol"
+	case BoundGenericClass:
+		return "BoundGenericClass"
+	case BoundGenericStruct:
+		return "BoundGenericStruct"
+	case BoundGenericEnum:
+		return "BoundGenericEnum"
+	case BoundGenericProtocol:
+		return "BoundGenericProtocol"
+	case TypeList:
+		return "TypeList"
+	case Tuple:
+		return "Tuple"
+	case FunctionType:
+		return "FunctionType"
+	case Function:
+		return "Function"
+	case Metadata:
+		return "Metadata"
+	case MetadataAccessor:
+		return "MetadataAccessor"
+	case ProtocolWitnessTable:
+		return "ProtocolWitnessTable"
+	case ProtocolConformanceDescriptor:
+		return "ProtocolConformanceDescriptor"
+	case Global:
+		return "Global"
+	default:
+		return fmt.Sprintf("Kind(%d)", uint8(k))
+	}
+}
+
+// Node is one node of a demangled symbol tree. Nominal types (Class, Struct,
+// Enum, Protocol) carry their enclosing context (the module's Identifier or
+// an enclosing nominal type; nil if the mangling names none) as Children[0]
+// and their Identifier as Children[1]; bound generic variants instead carry
+// the unbound nominal type as Children[0] and their generic arguments as
+// Children[1:]. A Function carries its enclosing context as Children[0]
+// (the module's Identifier if declared directly in a module), its declared
+// name as Children[1], its argument Tuple as Children[2] (empty for a
+// niladic function) and its return type as Children[3].
+type Node struct {
+	Kind     Kind
+	Text     string
+	Children []*Node
+}
+
+func node(k Kind, children ...*Node) *Node { return &Node{Kind: k, Children: children} }
+func leaf(k Kind, text string) *Node       { return &Node{Kind: k, Text: text} }
+
+// Context returns the parent context of a nominal type node, or nil if n is
+// not a nominal type or has no enclosing context.
+func (n *Node) Context() *Node {
+	if !n.isNominal() || len(n.Children) == 0 {
+		return nil
+	}
+	if n.isBoundGeneric() {
+		return n.Children[0].Context()
+	}
+	return n.Children[0]
+}
+
+// Name returns the identifier text of a nominal type or Identifier node.
+func (n *Node) Name() string {
+	switch {
+	case n.Kind == Identifier:
+		return n.Text
+	case n.isBoundGeneric() && len(n.Children) > 0:
+		return n.Children[0].Name()
+	case n.isNominal() && len(n.Children) > 1:
+		return n.Children[1].Name()
+	default:
+		return n.Text
+	}
+}
+
+func (n *Node) isNominal() bool {
+	switch n.Kind {
+	case Class, Struct, Enum, Protocol,
+		BoundGenericClass, BoundGenericStruct, BoundGenericEnum, BoundGenericProtocol:
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *Node) isBoundGeneric() bool {
+	switch n.Kind {
+	case BoundGenericClass, BoundGenericStruct, BoundGenericEnum, BoundGenericProtocol:
+		return true
+	default:
+		return false
+	}
+}
+
+// isContext reports whether n can serve as the enclosing context of an
+// entity (a module, or a nominal type) rather than being a type used as a
+// tuple element or function argument/return type in its own right.
+func (n *Node) isContext() bool {
+	return n.Kind == Module || n.isNominal()
+}
+
+const (
+	prefixCurrent    = "$s"
+	prefixCurrentAlt = "$S"
+	prefixLegacy     = "_T0"
+)
+
+func stripPrefix(s string) (string, bool) {
+	switch {
+	case strings.HasPrefix(s, prefixCurrent):
+		return s[len(prefixCurrent):], true
+	case strings.HasPrefix(s, prefixCurrentAlt):
+		return s[len(prefixCurrentAlt):], true
+	case strings.HasPrefix(s, prefixLegacy):
+		return s[len(prefixLegacy):], true
+	default:
+		return "", false
+	}
+}
+
+// Demangle parses a Swift mangled symbol name (in any of the `$s`, `$S` or
+// legacy `_T0` forms) and returns the root of its parsed node tree.
+func Demangle(mangled string) (*Node, error) {
+	sym, ok := stripPrefix(mangled)
+	if !ok {
+		return nil, fmt.Errorf("demangle: %q is not a Swift mangled name", mangled)
+	}
+	d := &demangler{input: sym}
+	for d.pos < len(d.input) {
+		if err := d.demangleOperator(); err != nil {
+			return nil, err
+		}
+	}
+	if len(d.stack) == 0 {
+		return nil, fmt.Errorf("demangle: %q decoded to nothing", mangled)
+	}
+	return node(Global, d.stack...), nil
+}
+
+// DemangleSimple is a convenience wrapper that returns the pretty-printed
+// form of mangled, or mangled itself unchanged if it cannot be demangled.
+func DemangleSimple(mangled string) string {
+	n, err := Demangle(mangled)
+	if err != nil {
+		return mangled
+	}
+	return n.String()
+}
+
+// demangler walks the postfix mangling grammar left to right, pushing each
+// fully-parsed production onto a stack; operators further along the string
+// pop the productions they operate on and push the combined result. This
+// mirrors the structure of Swift's own Demangler.
+type demangler struct {
+	input string
+	pos   int
+	stack []*Node
+	subs  []*Node // substitution table, referenced by A0, A1, ...; letter-form indices (Ab, ...) are not parsed
+}
+
+func (d *demangler) push(n *Node) *Node {
+	d.stack = append(d.stack, n)
+	return n
+}
+
+func (d *demangler) pop() *Node {
+	if len(d.stack) == 0 {
+		return nil
+	}
+	n := d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+	return n
+}
+
+func (d *demangler) peek() byte {
+	if d.pos >= len(d.input) {
+		return 0
+	}
+	return d.input[d.pos]
+}
+
+func (d *demangler) addSubstitution(n *Node) {
+	d.subs = append(d.subs, n)
+}
+
+func (d *demangler) demangleOperator() error {
+	c := d.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		return d.demangleIdentifier()
+	case c == 'A':
+		return d.demangleSubstitution()
+	case c == 'C':
+		d.pos++
+		return d.demangleNominal(Class)
+	case c == 'V':
+		d.pos++
+		return d.demangleNominal(Struct)
+	case c == 'O':
+		d.pos++
+		return d.demangleNominal(Enum)
+	case c == 'P':
+		d.pos++
+		return d.demangleNominal(Protocol)
+	case c == 'y':
+		d.pos++
+		d.push(leaf(TypeList, "")) // generic-argument-list marker
+		return nil
+	case c == 'G':
+		d.pos++
+		return d.demangleBoundGeneric()
+	case c == 't':
+		d.pos++
+		return d.demangleTuple()
+	case c == 'c':
+		d.pos++
+		return d.demangleFunctionType()
+	case c == 'F':
+		d.pos++
+		return d.demangleFunction()
+	case c == 'M':
+		d.pos++
+		if d.peek() == 'a' {
+			d.pos++
+			return d.demangleWrap(MetadataAccessor)
+		}
+		return d.demangleWrap(Metadata)
+	case c == 'W':
+		d.pos++
+		switch d.next() {
+		case 'P':
+			return d.demangleWrap(ProtocolWitnessTable)
+		case 'a':
+			return d.demangleWrap(ProtocolConformanceDescriptor)
+		default:
+			return fmt.Errorf("demangle: unsupported witness operator at offset %d", d.pos)
+		}
+	default:
+		return fmt.Errorf("demangle: unrecognized operator %q at offset %d", c, d.pos)
+	}
+}
+
+func (d *demangler) next() byte {
+	c := d.peek()
+	d.pos++
+	return c
+}
+
+// demangleIdentifier parses `<length><chars>`, or a punycode-encoded
+// identifier prefixed with `00<length><chars>`.
+func (d *demangler) demangleIdentifier() error {
+	punycode := false
+	if d.peek() == '0' && d.pos+1 < len(d.input) && d.input[d.pos+1] == '0' {
+		punycode = true
+		d.pos += 2
+	}
+	start := d.pos
+	for d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '9' {
+		d.pos++
+	}
+	if d.pos == start {
+		return fmt.Errorf("demangle: expected identifier length at offset %d", start)
+	}
+	n, err := strconv.Atoi(d.input[start:d.pos])
+	if err != nil {
+		return err
+	}
+	if d.pos+n > len(d.input) {
+		return fmt.Errorf("demangle: identifier length %d overruns input", n)
+	}
+	text := d.input[d.pos : d.pos+n]
+	d.pos += n
+	if punycode {
+		decoded, ok := decodeSwiftPunycode(text)
+		if !ok {
+			return fmt.Errorf("demangle: malformed punycode identifier %q", text)
+		}
+		text = decoded
+	}
+	d.push(leaf(Identifier, text))
+	return nil
+}
+
+// demangleSubstitution parses `A<decimal index>` back-references into the
+// substitution table built up as nominal types are parsed; module
+// identifiers are not registered in it.
+func (d *demangler) demangleSubstitution() error {
+	d.pos++ // 'A'
+	start := d.pos
+	for d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '9' {
+		d.pos++
+	}
+	if d.pos == start {
+		return fmt.Errorf("demangle: expected substitution index at offset %d", start)
+	}
+	idx, err := strconv.Atoi(d.input[start:d.pos])
+	if err != nil {
+		return err
+	}
+	if idx < 0 || idx >= len(d.subs) {
+		return fmt.Errorf("demangle: substitution A%d out of range (have %d)", idx, len(d.subs))
+	}
+	d.push(d.subs[idx])
+	return nil
+}
+
+// demangleNominal pops a name and its enclosing context (module or another
+// nominal type, if any) and combines them into a single context node,
+// registering it as a new substitution.
+func (d *demangler) demangleNominal(kind Kind) error {
+	name := d.pop()
+	if name == nil {
+		return fmt.Errorf("demangle: nominal type missing identifier")
+	}
+	var ctx *Node
+	if len(d.stack) > 0 {
+		ctx = d.pop()
+	}
+	n := node(kind, ctx, name)
+	d.addSubstitution(n)
+	d.push(n)
+	return nil
+}
+
+// demangleBoundGeneric closes a `y...G` generic-argument-list by collecting
+// every type pushed since the most recent TypeList marker and attaching it
+// to the unbound nominal type beneath it.
+func (d *demangler) demangleBoundGeneric() error {
+	var args []*Node
+	for len(d.stack) > 0 {
+		top := d.pop()
+		if top.Kind == TypeList {
+			break
+		}
+		args = append([]*Node{top}, args...)
+	}
+	base := d.pop()
+	if base == nil {
+		return fmt.Errorf("demangle: bound generic missing base type")
+	}
+	var kind Kind
+	switch base.Kind {
+	case Class:
+		kind = BoundGenericClass
+	case Struct:
+		kind = BoundGenericStruct
+	case Enum:
+		kind = BoundGenericEnum
+	case Protocol:
+		kind = BoundGenericProtocol
+	default:
+		return fmt.Errorf("demangle: %s is not a generic nominal type", base.Kind)
+	}
+	d.push(node(kind, append([]*Node{base}, args...)...))
+	return nil
+}
+
+// demangleTuple collects the types produced since the symbol's enclosing
+// context (and, if present, its declared name) into a single Tuple node.
+// stack[0], when present, holds that enclosing context (the module/nominal-
+// type chain a method is declared on, built once at the very bottom of the
+// stack and never itself a tuple element) and must never be absorbed into
+// the tuple the way taking the whole stack wholesale would: a class/struct
+// context sitting there when a method's parameter tuple is reached would
+// otherwise leak in as a bogus element, or be left with nothing for a later
+// `F` to consume.
+//
+// When a context is present, stack[1] (if a bare Identifier) is the decl-
+// name of the entity that context belongs to — only `F` ever consumes a
+// context off the stack, and a function's decl-name always sits directly
+// above it — so it's protected the same way, leaving only the real
+// argument types to become Tuple elements.
+func (d *demangler) demangleTuple() error {
+	floor := 0
+	if len(d.stack) > 0 && d.stack[0].isContext() {
+		floor = 1
+		if len(d.stack) > 1 && d.stack[1].Kind == Identifier {
+			floor = 2
+		}
+	}
+	elems := append([]*Node(nil), d.stack[floor:]...)
+	d.stack = d.stack[:floor]
+	d.push(node(Tuple, elems...))
+	return nil
+}
+
+// demangleFunctionType marks the preceding (argument-tuple, return-type)
+// pair as an escaping Swift function type.
+func (d *demangler) demangleFunctionType() error {
+	ret := d.pop()
+	args := d.pop()
+	if ret == nil || args == nil {
+		return fmt.Errorf("demangle: function type missing argument/return types")
+	}
+	d.push(node(FunctionType, args, ret))
+	return nil
+}
+
+// demangleFunction closes a full function mangling: the declared name and
+// its enclosing context (protected on the stack by demangleTuple, or, for a
+// niladic function, sitting there untouched), the argument tuple (absent,
+// meaning no arguments, unless a `t` produced one) and the return type.
+// This combines them into a single Function node the same way
+// demangleNominal combines a nominal type's name and context.
+func (d *demangler) demangleFunction() error {
+	ret := d.pop()
+	if ret == nil {
+		return fmt.Errorf("demangle: function missing return type")
+	}
+	args := node(Tuple)
+	if len(d.stack) > 0 && d.stack[len(d.stack)-1].Kind == Tuple {
+		args = d.pop()
+	}
+	name := d.pop()
+	if name == nil {
+		return fmt.Errorf("demangle: function missing declaration name")
+	}
+	var ctx *Node
+	if len(d.stack) > 0 {
+		ctx = d.pop()
+	}
+	d.push(node(Function, ctx, name, args, ret))
+	return nil
+}
+
+func (d *demangler) demangleWrap(kind Kind) error {
+	target := d.pop()
+	if target == nil {
+		return fmt.Errorf("demangle: %s missing target type", kind)
+	}
+	d.push(node(kind, target))
+	return nil
+}
+
+// String renders n using the canonical `Module.Type<Args>.method(Arg) -> Ret`
+// form used by swift-demangle.
+func (n *Node) String() string {
+	return Print(n)
+}
+
+// Print pretty-prints a parsed symbol tree.
+func Print(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	switch n.Kind {
+	case Global:
+		var parts []string
+		for _, c := range n.Children {
+			parts = append(parts, Print(c))
+		}
+		return strings.Join(parts, "")
+	case Identifier:
+		return n.Text
+	case Class, Struct, Enum, Protocol:
+		return printContextPath(n)
+	case BoundGenericClass, BoundGenericStruct, BoundGenericEnum, BoundGenericProtocol:
+		base := n.Children[0]
+		args := n.Children[1:]
+		var argStrs []string
+		for _, a := range args {
+			argStrs = append(argStrs, Print(a))
+		}
+		return fmt.Sprintf("%s<%s>", printContextPath(base), strings.Join(argStrs, ", "))
+	case TypeList:
+		return ""
+	case Tuple:
+		var elems []string
+		for _, c := range n.Children {
+			elems = append(elems, Print(c))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(elems, ", "))
+	case FunctionType:
+		return fmt.Sprintf("%s -> %s", Print(n.Children[0]), Print(n.Children[1]))
+	case Function:
+		ctx, name, args, ret := n.Children[0], n.Children[1], n.Children[2], n.Children[3]
+		var prefix string
+		if ctx != nil {
+			prefix = printContextPath(ctx) + "."
+		}
+		return fmt.Sprintf("%s%s%s -> %s", prefix, Print(name), Print(args), Print(ret))
+	case Metadata:
+		return fmt.Sprintf("type metadata for %s", Print(n.Children[0]))
+	case MetadataAccessor:
+		return fmt.Sprintf("metadata accessor for %s", Print(n.Children[0]))
+	case ProtocolWitnessTable:
+		return fmt.Sprintf("protocol witness table for %s", Print(n.Children[0]))
+	case ProtocolConformanceDescriptor:
+		return fmt.Sprintf("protocol conformance descriptor for %s", Print(n.Children[0]))
+	default:
+		return n.Text
+	}
+}
+
+// printContextPath renders a nominal type's fully-qualified dotted name,
+// e.g. "Module.Outer.Inner".
+func printContextPath(n *Node) string {
+	var parts []string
+	for cur := n; cur != nil; {
+		switch cur.Kind {
+		case Identifier:
+			parts = append([]string{cur.Text}, parts...)
+			cur = nil
+		case Class, Struct, Enum, Protocol:
+			parts = append([]string{cur.Name()}, parts...)
+			cur = cur.Context()
+		default:
+			cur = nil
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,43 @@
+package demangle
+
+import "testing"
+
+func TestDemangle(t *testing.T) {
+	tests := []struct {
+		name    string
+		mangled string
+		want    string
+	}{
+		{
+			name:    "module",
+			mangled: "$s4main3FooV",
+			want:    "main.Foo",
+		},
+		{
+			name:    "nested type",
+			mangled: "$s4main3FooC3BarV",
+			want:    "main.Foo.Bar",
+		},
+		{
+			name:    "niladic method",
+			mangled: "$s4main3FooC3bar1RF",
+			want:    "main.Foo.bar() -> R",
+		},
+		{
+			name:    "multi-arg method",
+			mangled: "$s4main3FooC3bar1a1bt1RF",
+			want:    "main.Foo.bar(a, b) -> R",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Demangle(tt.mangled)
+			if err != nil {
+				t.Fatalf("Demangle(%q) returned error: %v", tt.mangled, err)
+			}
+			if got := n.String(); got != tt.want {
+				t.Errorf("Demangle(%q).String() = %q, want %q", tt.mangled, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package demangle
+
+// decodeSwiftPunycode decodes the punycode variant Swift uses for
+// non-ASCII identifiers, which is RFC 3492 punycode with '_' in place of
+// '-' as the delimiter between the basic-code-point prefix and the
+// extended-code-point digits.
+//
+// ref: swift/lib/Demangling/Punycode.cpp
+//
+// FIXME: Swift additionally reorders the digits within each delta (most
+// significant digit first) relative to stock RFC 3492; this decoder does
+// not account for that, so identifiers containing non-ASCII characters may
+// come out wrong. ASCII-only identifiers (the common case) decode correctly
+// since they pass through unmodified.
+func decodeSwiftPunycode(s string) (string, bool) {
+	const (
+		base        = 36
+		tMin        = 1
+		tMax        = 26
+		skew        = 38
+		damp        = 700
+		initialBias = 72
+		initialN    = 128
+	)
+
+	delim := -1
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '_' {
+			delim = i
+			break
+		}
+	}
+
+	var output []rune
+	rest := s
+	if delim >= 0 {
+		output = []rune(s[:delim])
+		rest = s[delim+1:]
+	}
+
+	digit := func(c byte) (int, bool) {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return int(c - 'a'), true
+		case c >= 'A' && c <= 'Z':
+			return int(c - 'A'), true
+		case c >= '0' && c <= '9':
+			return int(c-'0') + 26, true
+		default:
+			return 0, false
+		}
+	}
+
+	n := initialN
+	i := 0
+	bias := initialBias
+	pos := 0
+	for pos < len(rest) {
+		oldI := i
+		w := 1
+		for k := base; ; k += base {
+			if pos >= len(rest) {
+				return "", false
+			}
+			d, ok := digit(rest[pos])
+			if !ok {
+				return "", false
+			}
+			pos++
+			i += d * w
+			t := k - bias
+			if t < tMin {
+				t = tMin
+			} else if t > tMax {
+				t = tMax
+			}
+			if d < t {
+				break
+			}
+			w *= base - t
+		}
+		bias = adaptBias(i-oldI, len(output)+1, oldI == 0, damp, skew, tMin, tMax, base)
+		n += i / (len(output) + 1)
+		i = i % (len(output) + 1)
+		if n > 0x10FFFF {
+			return "", false
+		}
+		// insert rune(n) at position i
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+	}
+	return string(output), true
+}
+
+func adaptBias(delta, numPoints int, firstTime bool, damp, skew, tMin, tMax, base int) int {
+	if firstTime {
+		delta /= damp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((base-tMin)*tMax)/2 {
+		delta /= base - tMin
+		k += base
+	}
+	return k + (base-tMin+1)*delta/(delta+skew)
+}
@@ -0,0 +1,195 @@
+// Package dump renders already-parsed Swift reflection metadata as JSON
+// matching the schema swift-reflection-dump produces, so Go tooling can
+// consume it without shelling out to that tool.
+//
+// This only covers the sections this repository parses elsewhere: field
+// records (__swift5_fieldmd, via types/swift/fields), protocol descriptors
+// (__swift5_protos, via types/swift/protocols), and protocol conformance
+// descriptors (__swift5_proto, also types/swift/protocols). Associated
+// types (__swift5_assocty), builtin type descriptors (__swift5_builtin),
+// and capture descriptors (__swift5_capture) aren't modeled by any package
+// in this tree yet, so Reflect omits them rather than fake a shape for data
+// it never received.
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/blacktop/go-macho/types/swift/demangle"
+	"github.com/blacktop/go-macho/types/swift/fields"
+	"github.com/blacktop/go-macho/types/swift/protocols"
+)
+
+// Input is everything Reflect needs: every field descriptor, protocol, and
+// protocol conformance parsed out of one or more Mach-O images.
+type Input struct {
+	Fields       []fields.Field
+	Protocols    []protocols.Protocol
+	Conformances []*protocols.ConformanceDescriptor
+}
+
+// Output is the top-level JSON document Reflect produces.
+type Output struct {
+	Types        []Type        `json:"types,omitempty"`
+	Protocols    []Protocol    `json:"protocols,omitempty"`
+	Conformances []Conformance `json:"conformances,omitempty"`
+}
+
+// FieldFlags is a field record's flag bits, broken out by name.
+type FieldFlags struct {
+	IndirectCase bool `json:"indirectCase"`
+	Var          bool `json:"var"`
+	Artificial   bool `json:"artificial"`
+}
+
+// Field is one field of a reflected Type.
+type Field struct {
+	Name          string     `json:"name"`
+	MangledType   string     `json:"mangledType"`
+	DemangledType string     `json:"demangledType"`
+	Flags         FieldFlags `json:"flags"`
+}
+
+// Type is one reflected field descriptor: a class, struct, or enum and its
+// fields.
+type Type struct {
+	MangledName   string  `json:"mangledName"`
+	DemangledName string  `json:"demangledName"`
+	Kind          string  `json:"kind"`
+	Superclass    string  `json:"superclass,omitempty"`
+	Fields        []Field `json:"fields"`
+}
+
+// Requirement is one entry of a reflected Protocol's requirement list.
+type Requirement struct {
+	Kind          string `json:"kind"`
+	IsInstance    bool   `json:"isInstance"`
+	IsAsync       bool   `json:"isAsync"`
+	Discriminator uint16 `json:"discriminator"`
+}
+
+// SignatureRequirement is one entry of a reflected Protocol's requirement
+// signature: a generic requirement the protocol's own Self type must
+// satisfy. Param and Target are the raw mangled-name/union offsets from
+// TargetGenericRequirementDescriptor, since this repository does not yet
+// resolve them to text.
+type SignatureRequirement struct {
+	Param  int32  `json:"param"`
+	Kind   string `json:"kind"`
+	Target int32  `json:"target"`
+}
+
+// Protocol is one reflected protocol descriptor.
+type Protocol struct {
+	Name                  string                 `json:"name"`
+	Requirements          []Requirement          `json:"requirements"`
+	SignatureRequirements []SignatureRequirement `json:"signatureRequirements"`
+}
+
+// Conformance is one reflected protocol conformance descriptor.
+type Conformance struct {
+	Protocol                string   `json:"protocol"`
+	Type                    string   `json:"type"`
+	Retroactive             bool     `json:"retroactive"`
+	Synthesized             bool     `json:"synthesized"`
+	ConditionalRequirements int      `json:"conditionalRequirements"`
+	ResilientWitnesses      []string `json:"resilientWitnesses,omitempty"`
+}
+
+// Reflect renders in into the Output schema, equivalent to
+// swift-reflection-dump's own report for the sections this package covers.
+// Marshal the result with encoding/json to get the JSON document itself,
+// or use ReflectJSON.
+func Reflect(in Input) Output {
+	var out Output
+	for _, f := range in.Fields {
+		out.Types = append(out.Types, reflectType(f))
+	}
+	for _, p := range in.Protocols {
+		out.Protocols = append(out.Protocols, reflectProtocol(p))
+	}
+	for _, cd := range in.Conformances {
+		out.Conformances = append(out.Conformances, reflectConformance(cd))
+	}
+	return out
+}
+
+// ReflectJSON is a convenience wrapper around Reflect that marshals the
+// result to indented JSON.
+func ReflectJSON(in Input) ([]byte, error) {
+	return json.MarshalIndent(Reflect(in), "", "  ")
+}
+
+func reflectType(f fields.Field) Type {
+	t := Type{
+		MangledName:   f.MangledType,
+		DemangledName: demangle.DemangleSimple(f.MangledType),
+		Kind:          fmt.Sprintf("%s", f.Descriptor.Kind),
+		Superclass:    f.SuperClass,
+	}
+	for _, r := range f.Records {
+		t.Fields = append(t.Fields, Field{
+			Name:          r.Name,
+			MangledType:   r.MangledType,
+			DemangledType: r.DemangledType(),
+			Flags:         fieldFlagsOf(r.Flags),
+		})
+	}
+	return t
+}
+
+// fieldFlagsOf derives FieldFlags from a FieldRecord's raw FieldRecordFlags
+// bitmask via its bit-test helpers, so combined flags (e.g. an indirect,
+// mutable case) come through correctly instead of being lost to a
+// stringer-rendered-string heuristic.
+func fieldFlagsOf(flags fields.FieldRecordFlags) FieldFlags {
+	return FieldFlags{
+		IndirectCase: flags.IsIndirectCase(),
+		Var:          flags.IsVar(),
+		Artificial:   flags.IsArtificial(),
+	}
+}
+
+func reflectProtocol(p protocols.Protocol) Protocol {
+	out := Protocol{Name: p.DemangledName()}
+	for _, req := range p.Requirements {
+		out.Requirements = append(out.Requirements, Requirement{
+			Kind:          fmt.Sprintf("%s", req.Flags.Kind()),
+			IsInstance:    req.Flags.IsInstance(),
+			IsAsync:       req.Flags.IsAsync(),
+			Discriminator: req.Flags.ExtraDiscriminator(),
+		})
+	}
+	for _, req := range p.SignatureRequirements {
+		out.SignatureRequirements = append(out.SignatureRequirements, SignatureRequirement{
+			Param:  req.Param,
+			Kind:   fmt.Sprintf("%s", req.Flags.Kind()),
+			Target: req.TypeOrProtocolOrConformanceOrLayout,
+		})
+	}
+	return out
+}
+
+func reflectConformance(cd *protocols.ConformanceDescriptor) Conformance {
+	c := Conformance{
+		Protocol:                cd.DemangledProtocol(),
+		Retroactive:             cd.Flags.IsRetroactive(),
+		Synthesized:             cd.Flags.IsSynthesizedNonUnique(),
+		ConditionalRequirements: cd.Flags.GetNumConditionalRequirements(),
+	}
+	if cd.TypeRef != nil {
+		c.Type = cd.TypeRef.Name
+	}
+	for _, w := range cd.ResilientWitnesses {
+		c.ResilientWitnesses = append(c.ResilientWitnesses, resilientWitnessName(w))
+	}
+	return c
+}
+
+func resilientWitnessName(w protocols.ResilientWitness) string {
+	if w.Symbol != "" {
+		return w.Symbol
+	}
+	return fmt.Sprintf("0x%x", w.Address)
+}
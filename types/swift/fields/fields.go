@@ -1,5 +1,13 @@
 package fields
 
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/blacktop/go-macho/types/swift/demangle"
+)
+
 //go:generate stringer -type=FieldDescriptorKind,FieldRecordFlags -output fields_string.go
 
 // ref: swift/include/swift/Reflection/Records.h
@@ -22,6 +30,15 @@ const (
 	IsArtificial FieldRecordFlags = 0x4
 )
 
+// IsIndirectCase reports whether f has the indirect-enum-case bit set.
+func (f FieldRecordFlags) IsIndirectCase() bool { return f&IsIndirectCase != 0 }
+
+// IsVar reports whether f has the mutable-var-property bit set.
+func (f FieldRecordFlags) IsVar() bool { return f&IsVar != 0 }
+
+// IsArtificial reports whether f has the artificial-field bit set.
+func (f FieldRecordFlags) IsArtificial() bool { return f&IsArtificial != 0 }
+
 type FieldDescriptorKind uint16
 
 const (
@@ -31,11 +48,9 @@ const (
 	Enum
 
 	// Fixed-size multi-payload enums have a special descriptor format that
-	// encodes spare bits.
-	//
-	// FIXME: Actually implement this. For now, a descriptor with this kind
-	// just means we also have a builtin descriptor from which we get the
-	// size and alignment.
+	// encodes spare bits: a trailing PayloadSizeInBits and SpareBits vector
+	// tail-allocated after the standard FieldRecords, read by
+	// ReadMultiPayloadEnumTail.
 	MultiPayloadEnum
 
 	// A Swift opaque protocol. There are no fields, just a record for the
@@ -65,7 +80,7 @@ type FDHeader struct {
 type FieldRecord struct {
 	Name        string
 	MangledType string
-	Flags       string
+	Flags       FieldRecordFlags
 }
 
 type FieldRecordType struct {
@@ -77,6 +92,37 @@ type FieldRecordType struct {
 type FieldDescriptor struct {
 	FDHeader
 	FieldRecords []FieldRecordType
+
+	// PayloadSizeInBits and SpareBits are only populated when Kind is
+	// MultiPayloadEnum; see ReadMultiPayloadEnumTail.
+	PayloadSizeInBits uint32
+	SpareBits         []uint32
+}
+
+// ReadMultiPayloadEnumTail reads the trailing multi-payload enum layout
+// that follows fd's standard FieldRecords when fd.Kind is
+// MultiPayloadEnum: a uint32 payload-size-in-bits, followed by one
+// little-endian spare-bit mask per payload case, each
+// ceil(PayloadSizeInBits/32) words wide. numPayloadCases is the number of
+// fd.FieldRecords entries that carry an associated value (as opposed to
+// empty, no-payload cases).
+// ref: swift/include/swift/Reflection/Records.h
+func (fd *FieldDescriptor) ReadMultiPayloadEnumTail(r io.Reader, numPayloadCases int) error {
+	if fd.Kind != MultiPayloadEnum {
+		return fmt.Errorf("field descriptor kind is %s, not MultiPayloadEnum", fd.Kind)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fd.PayloadSizeInBits); err != nil {
+		return fmt.Errorf("failed to read multi-payload enum payload size: %v", err)
+	}
+	wordsPerCase := int((fd.PayloadSizeInBits + 31) / 32)
+	fd.SpareBits = make([]uint32, wordsPerCase*numPayloadCases)
+	if len(fd.SpareBits) == 0 {
+		return nil
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fd.SpareBits); err != nil {
+		return fmt.Errorf("failed to read multi-payload enum spare bits: %v", err)
+	}
+	return nil
 }
 
 type Field struct {
@@ -89,6 +135,77 @@ type Field struct {
 	Descriptor  FieldDescriptor
 }
 
+// DemangledType returns the field record's type pretty-printed from its
+// mangled form, falling back to the raw mangled string if it can't be
+// parsed.
+func (r FieldRecord) DemangledType() string {
+	return demangle.DemangleSimple(r.MangledType)
+}
+
+// PayloadCases returns the number of this enum's cases that carry an
+// associated value.
+func (f Field) PayloadCases() int {
+	var n int
+	for _, r := range f.Records {
+		if r.MangledType != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// NoPayloadCases returns the number of this enum's cases that carry no
+// associated value.
+func (f Field) NoPayloadCases() int {
+	return len(f.Records) - f.PayloadCases()
+}
+
+// TagBits returns the number of payload bits that are spare across every
+// one of a multi-payload enum's cases, i.e. the portion of the payload
+// representation guaranteed unused by any case's value and therefore
+// available to store the enum's tag without needing extra storage.
+//
+// This models the bit-counting half of the Swift runtime's
+// getEnumTagCounts; it does not replicate that algorithm's handling of
+// spilling no-payload case tags into extra tag bytes once the spare bits
+// are exhausted.
+func (f Field) TagBits() int {
+	if f.Descriptor.Kind != MultiPayloadEnum {
+		return 0
+	}
+	cases := f.PayloadCases()
+	if cases == 0 || len(f.Descriptor.SpareBits) == 0 {
+		return 0
+	}
+	wordsPerCase := len(f.Descriptor.SpareBits) / cases
+	if wordsPerCase == 0 {
+		return 0
+	}
+	common := make([]uint32, wordsPerCase)
+	for i := range common {
+		common[i] = ^uint32(0)
+	}
+	for c := 0; c < cases; c++ {
+		for w := 0; w < wordsPerCase; w++ {
+			common[w] &= f.Descriptor.SpareBits[c*wordsPerCase+w]
+		}
+	}
+	var bits int
+	for _, w := range common {
+		bits += popcount(w)
+	}
+	return bits
+}
+
+func popcount(w uint32) int {
+	var n int
+	for w != 0 {
+		w &= w - 1
+		n++
+	}
+	return n
+}
+
 func (f Field) IsEnum() bool {
 	return f.Descriptor.Kind == Enum || f.Descriptor.Kind == MultiPayloadEnum
 }
@@ -1,8 +1,11 @@
 package protocols
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
 
+	"github.com/blacktop/go-macho/types/swift/demangle"
 	"github.com/blacktop/go-macho/types/swift/types"
 )
 
@@ -10,6 +13,7 @@ import (
 
 // Protocol swift protocol object
 type Protocol struct {
+	Address        uint64
 	Name           string
 	AssociatedType string
 	Parent         *Protocol
@@ -18,6 +22,19 @@ type Protocol struct {
 	Requirements          []TargetProtocolRequirement
 }
 
+// DemangledName pretty-prints the protocol's name from its mangled form,
+// falling back to the raw Name if it can't be parsed.
+func (p Protocol) DemangledName() string {
+	return demangle.DemangleSimple(p.Name)
+}
+
+// DemangledAssociatedType pretty-prints the protocol's associated type
+// names from their mangled form, falling back to the raw string if it
+// can't be parsed.
+func (p Protocol) DemangledAssociatedType() string {
+	return demangle.DemangleSimple(p.AssociatedType)
+}
+
 func (p Protocol) String() string {
 	var associateType string
 	if p.Descriptor.AssociatedTypeNamesOffset != 0 {
@@ -275,11 +292,199 @@ type TargetProtocolConformanceDescriptor struct {
 
 type ConformanceDescriptor struct {
 	TargetProtocolConformanceDescriptor
-	Protocol     string
-	TypeRef      *types.TypeDescriptor
-	WitnessTable int32
+	Protocol    string
+	ProtocolRef *Protocol // resolved target of ProtocolOffsest, when the protocol descriptor it points to has also been parsed
+	TypeRef     *types.TypeDescriptor
+
+	// ConditionalRequirements is populated when
+	// Flags.GetNumConditionalRequirements() is non-zero: the generic
+	// requirements that must hold for this conformance to apply, tail-read
+	// the same way ReadResilientWitnesses reads the block that follows them.
+	ConditionalRequirements []TargetGenericRequirementDescriptor
+
+	WitnessTable       int32
+	ResilientWitnesses []ResilientWitness
+}
+
+// DemangledProtocol pretty-prints the conformed-to protocol's name from its
+// mangled form, falling back to the raw Protocol string if it can't be
+// parsed.
+func (c ConformanceDescriptor) DemangledProtocol() string {
+	return demangle.DemangleSimple(c.Protocol)
 }
 
 type TargetWitnessTable struct {
 	Description int32
 }
+
+// TargetResilientWitnessesHeader precedes the resilient witness entries
+// that newer Swift ABIs tail-allocate on a protocol conformance descriptor,
+// right after its conditional requirements, when
+// ConformanceFlags.HasResilientWitnesses is set.
+// ref: swift/ABI/Metadata.h - TargetResilientWitnessesHeader
+type TargetResilientWitnessesHeader struct {
+	NumWitnesses uint32
+}
+
+// TargetResilientWitness is a single tail-allocated resilient witness: the
+// protocol requirement it satisfies and the implementation it's bound to,
+// both stored as relative offsets from the entry's own address.
+// ref: swift/ABI/Metadata.h - TargetResilientWitness
+type TargetResilientWitness struct {
+	RequirementOffset int32 // relative offset back into the protocol's requirement array
+	WitnessOffset     int32 // relative offset of the emitted implementation (function, associated-type or associated-conformance accessor, per the requirement's PRKind)
+}
+
+// ResilientWitness is a resolved TargetResilientWitness: the requirement it
+// implements, resolved against the conforming protocol's requirement list,
+// together with the address (and symbol, if known) of its implementation.
+type ResilientWitness struct {
+	Requirement *TargetProtocolRequirement
+	Symbol      string
+	Address     uint64
+}
+
+// ReadConditionalRequirements reads the conditional-requirements block
+// tail-allocated on a protocol conformance descriptor immediately after its
+// fixed fields: count many TargetGenericRequirementDescriptor entries, where
+// count is c.Flags.GetNumConditionalRequirements(). Callers must read this
+// block, if present, before any resilient-witnesses or generic-witness-table
+// block that follows.
+func ReadConditionalRequirements(r io.Reader, count int) ([]TargetGenericRequirementDescriptor, error) {
+	if count == 0 {
+		return nil, nil
+	}
+	out := make([]TargetGenericRequirementDescriptor, count)
+	if err := binary.Read(r, binary.LittleEndian, &out); err != nil {
+		return nil, fmt.Errorf("failed to read conditional requirements: %v", err)
+	}
+	return out, nil
+}
+
+// ReadResilientWitnesses reads the resilient-witnesses block tail-allocated
+// on a protocol conformance descriptor: a uint32 count followed by that
+// many TargetResilientWitness entries. Callers must only call this when
+// c.Flags.HasResilientWitnesses() is true, and must read it before any
+// TargetGenericWitnessTable that follows (c.Flags.HasGenericWitnessTable())
+// so the reader ends up positioned at the start of that next block.
+//
+// addr is the address of the uint32 count (i.e. the address immediately
+// following the conformance descriptor's conditional requirements).
+// protocolRequirementsAddr is the address of the conforming protocol's own
+// Requirements array, needed to resolve each entry's RequirementOffset.
+func ReadResilientWitnesses(r io.Reader, addr uint64, protocolRequirementsAddr uint64, requirements []TargetProtocolRequirement) ([]ResilientWitness, error) {
+	var hdr TargetResilientWitnessesHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, fmt.Errorf("failed to read resilient witnesses header: %v", err)
+	}
+	entryAddr := addr + uint64(binary.Size(hdr))
+	reqSize := uint64(binary.Size(TargetProtocolRequirement{}))
+	out := make([]ResilientWitness, 0, hdr.NumWitnesses)
+	for i := uint32(0); i < hdr.NumWitnesses; i++ {
+		var w TargetResilientWitness
+		if err := binary.Read(r, binary.LittleEndian, &w); err != nil {
+			return nil, fmt.Errorf("failed to read resilient witness %d: %v", i, err)
+		}
+		witnessFieldAddr := entryAddr + uint64(binary.Size(w.RequirementOffset))
+		rw := ResilientWitness{
+			Address: uint64(int64(witnessFieldAddr) + int64(w.WitnessOffset)),
+		}
+		reqAddr := uint64(int64(entryAddr) + int64(w.RequirementOffset))
+		if protocolRequirementsAddr != 0 && reqAddr >= protocolRequirementsAddr {
+			if idx := int((reqAddr - protocolRequirementsAddr) / reqSize); idx < len(requirements) {
+				rw.Requirement = &requirements[idx]
+			}
+		}
+		out = append(out, rw)
+		entryAddr += uint64(binary.Size(w))
+	}
+	return out, nil
+}
+
+// TargetGenericWitnessTable describes the out-of-line template used to
+// instantiate a conformance's witness table at runtime. It immediately
+// follows any resilient-witnesses block when
+// ConformanceFlags.HasGenericWitnessTable is set.
+// ref: swift/ABI/Metadata.h - TargetGenericWitnessTable
+type TargetGenericWitnessTable struct {
+	WitnessTableSizeInWords                                uint16
+	WitnessTablePrivateSizeInWordsAndRequiresInstantiation uint16
+	Instantiator                                           int32
+	PrivateData                                            int32
+}
+
+// WitnessTableFirstRequirementOffset is the number of fixed slots that
+// precede the first requirement's witness in every instantiated witness
+// table (slot 0 holds the conforming protocol's conformance descriptor).
+// The Swift runtime passes a "requirement base descriptor" - the
+// requirements array address offset back by this many slots - to
+// swift_getAssociatedTypeWitness so that a requirement's witness index is
+// a single subtraction from it.
+// ref: swift/ABI/MetadataValues.h - WitnessTableFirstRequirementOffset
+const WitnessTableFirstRequirementOffset = 1
+
+// RequirementBaseDescriptor returns the address of d's requirement base
+// descriptor, given the address of its Requirements array: the address a
+// witness index is computed relative to, per the ABI change that passes
+// this base (rather than the bare requirements array) to
+// swift_getAssociatedTypeWitness.
+func (d Descriptor) RequirementBaseDescriptor(requirementsAddr uint64) uint64 {
+	return requirementsAddr - WitnessTableFirstRequirementOffset*targetProtocolRequirementSize
+}
+
+// WitnessIndex returns the witness-table slot index of the requirement at
+// reqAddr, given d's requirement base descriptor address (as returned by
+// RequirementBaseDescriptor). This is the single subtraction
+// swift_getAssociatedTypeWitness performs once it's been handed a
+// requirement base descriptor instead of the bare requirements array.
+func (d Descriptor) WitnessIndex(requirementBaseDescriptorAddr, reqAddr uint64) int {
+	return int((reqAddr - requirementBaseDescriptorAddr) / targetProtocolRequirementSize)
+}
+
+// targetProtocolRequirementSize is the on-disk size of a
+// TargetProtocolRequirement entry, i.e. the stride of a protocol's
+// requirements array.
+var targetProtocolRequirementSize = uint64(binary.Size(TargetProtocolRequirement{}))
+
+// WitnessEntry is one resolved slot of an instantiated witness table: the
+// protocol requirement it satisfies, together with its primary and default
+// implementations.
+type WitnessEntry struct {
+	Kind        PRKind
+	Requirement string
+	Impl        uint64
+	DefaultImpl uint64
+}
+
+// Resolve walks t's instantiated slots alongside the conforming protocol's
+// requirement list, pairing up each witness-table slot (after skipping the
+// WitnessTableFirstRequirementOffset fixed slots that precede the first
+// requirement) with the requirement it implements, the way
+// swift-reflection-dump renders a witness table. requirementsAddr is the
+// address of the protocol's Requirements array (used to resolve each
+// requirement's relative DefaultImplementation offset); names, if non-nil,
+// gives the demangled/associated-type name for each requirement in
+// parallel with requirements.
+func (t TargetWitnessTable) Resolve(slots []uint64, requirementsAddr uint64, requirements []TargetProtocolRequirement, names []string) ([]WitnessEntry, error) {
+	need := WitnessTableFirstRequirementOffset + len(requirements)
+	if len(slots) < need {
+		return nil, fmt.Errorf("witness table has %d slots, want at least %d for %d requirements", len(slots), need, len(requirements))
+	}
+	out := make([]WitnessEntry, 0, len(requirements))
+	for i, req := range requirements {
+		entry := WitnessEntry{
+			Kind: req.Flags.Kind(),
+			Impl: slots[WitnessTableFirstRequirementOffset+i],
+		}
+		if i < len(names) {
+			entry.Requirement = names[i]
+		}
+		if req.DefaultImplementation != 0 {
+			reqAddr := requirementsAddr + uint64(i)*targetProtocolRequirementSize
+			defaultImplFieldAddr := reqAddr + uint64(binary.Size(req.Flags))
+			entry.DefaultImpl = uint64(int64(defaultImplFieldAddr) + int64(req.DefaultImplementation))
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}